@@ -0,0 +1,83 @@
+package cherrySession
+
+import (
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// NatsTransport is the default ClusterTransport, backed by a *nats.Conn. It
+// assumes conn is already connected; NewClusterGroup does not own its
+// lifecycle.
+type NatsTransport struct {
+	conn *nats.Conn
+}
+
+// NewNatsTransport wraps conn as a ClusterTransport.
+func NewNatsTransport(conn *nats.Conn) *NatsTransport {
+	return &NatsTransport{conn: conn}
+}
+
+// Publish implements ClusterTransport.
+func (t *NatsTransport) Publish(subject string, data []byte) error {
+	return t.conn.Publish(subject, data)
+}
+
+// Subscribe implements ClusterTransport with a plain (queue-less)
+// subscription, so every node attached to conn receives every message.
+func (t *NatsTransport) Subscribe(subject string, handler func(data []byte, reply func(data []byte))) (func(), error) {
+	sub, err := t.conn.Subscribe(subject, func(msg *nats.Msg) {
+		if msg.Reply == "" {
+			handler(msg.Data, nil)
+			return
+		}
+
+		handler(msg.Data, func(reply []byte) {
+			_ = t.conn.Publish(msg.Reply, reply)
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		_ = sub.Unsubscribe()
+	}, nil
+}
+
+// Request implements ClusterTransport as a NATS fan-in request: it publishes
+// a request and keeps collecting replies on an inbox subscription until
+// timeout elapses, since an unknown number of nodes may respond.
+func (t *NatsTransport) Request(subject string, data []byte, timeout time.Duration) ([][]byte, error) {
+	inbox := nats.NewInbox()
+
+	var replies [][]byte
+	sub, err := t.conn.SubscribeSync(inbox)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = sub.Unsubscribe()
+	}()
+
+	if err := t.conn.PublishRequest(subject, inbox, data); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+
+		msg, err := sub.NextMsg(remaining)
+		if err != nil {
+			break
+		}
+
+		replies = append(replies, msg.Data)
+	}
+
+	return replies, nil
+}
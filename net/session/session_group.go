@@ -7,6 +7,7 @@ import (
 	"github.com/cherry-game/cherry/profile"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 const (
@@ -25,14 +26,27 @@ type Group struct {
 	status   int32                   // channel current status
 	name     string                  // channel name
 	sessions map[facade.SID]*Session // session id map to session instance
+
+	tags        map[string]map[string]map[facade.SID]*Session // tag index: key -> value -> SID
+	sessionTags map[facade.SID]map[string]string              // reverse tag index: SID -> key -> value
+	joinTimes   map[facade.SID]time.Time                      // SID -> time Add was called
+
+	bp         *groupBackpressure // fan-out worker pool config, slow-consumer counters
+	membership *groupMembership   // suspended (disconnected-but-not-expired) UIDs
+
+	*EventEmitter // session.added/left, group.closed, broadcast/multicast.sent, push.error
 }
 
 // NewGroup returns a new group instance
 func NewGroup(n string) *Group {
 	return &Group{
-		status:   groupStatusWorking,
-		name:     n,
-		sessions: make(map[facade.SID]*Session),
+		status:       groupStatusWorking,
+		name:         n,
+		sessions:     make(map[facade.SID]*Session),
+		joinTimes:    make(map[facade.SID]time.Time),
+		bp:           newGroupBackpressure(),
+		membership:   newGroupMembership(),
+		EventEmitter: newEventEmitter(),
 	}
 }
 
@@ -50,15 +64,26 @@ func (c *Group) Member(uid int64) (*Session, error) {
 	return nil, cherryError.SessionMemberNotFound
 }
 
-// Members returns all member's UID in current group
-func (c *Group) Members() []int64 {
+// Members returns all member's UID in current group. Pass includeSuspended
+// as true to also include UIDs that Disconnect has suspended but whose
+// RejoinTTL has not yet elapsed.
+func (c *Group) Members(includeSuspended ...bool) []int64 {
 	c.mu.RLock()
-	defer c.mu.RUnlock()
-
 	var members []int64
 	for _, s := range c.sessions {
 		members = append(members, s.UID())
 	}
+	c.mu.RUnlock()
+
+	if len(includeSuspended) == 0 || !includeSuspended[0] {
+		return members
+	}
+
+	c.membership.mu.Lock()
+	for uid := range c.membership.records {
+		members = append(members, uid)
+	}
+	c.membership.mu.Unlock()
 
 	return members
 }
@@ -74,17 +99,17 @@ func (c *Group) Multicast(route string, v interface{}, filter SessionFilter) err
 	}
 
 	c.mu.RLock()
-	defer c.mu.RUnlock()
-
+	targets := make([]*Session, 0, len(c.sessions))
 	for _, s := range c.sessions {
-		if !filter(s) {
-			continue
-		}
-		if err := s.Push(route, v); err != nil {
-			s.Warn(err)
+		if filter(s) {
+			targets = append(targets, s)
 		}
 	}
+	c.mu.RUnlock()
+
+	c.fanOut(targets, route, v)
 
+	c.emit(EventMulticastSent, route, v)
 	return nil
 }
 
@@ -99,14 +124,15 @@ func (c *Group) Broadcast(route string, v interface{}) error {
 	}
 
 	c.mu.RLock()
-	defer c.mu.RUnlock()
-
+	targets := make([]*Session, 0, len(c.sessions))
 	for _, s := range c.sessions {
-		if err := s.Push(route, v); err != nil {
-			s.Warnf("push message error, SID[%d], UID[%d], Error[%s]", s.SID(), s.UID(), err.Error())
-		}
+		targets = append(targets, s)
 	}
+	c.mu.RUnlock()
+
+	c.fanOut(targets, route, v)
 
+	c.emit(EventBroadcastSent, route, v)
 	return nil
 }
 
@@ -127,18 +153,45 @@ func (c *Group) Add(session *Session) error {
 	}
 
 	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	id := session.sid
-	_, ok := c.sessions[session.sid]
-	if ok {
+	if _, ok := c.sessions[id]; ok {
+		c.mu.Unlock()
 		return cherryError.SessionDuplication
 	}
-
 	c.sessions[id] = session
+	c.joinTimes[id] = time.Now()
+	c.mu.Unlock()
+
+	c.emit(EventSessionAdded, session)
 	return nil
 }
 
+// JoinedAt returns when uid originally joined the group, preserved across
+// Disconnect/Rejoin. ok is false if uid is not a current or suspended member.
+func (c *Group) JoinedAt(uid int64) (joinedAt time.Time, ok bool) {
+	c.mu.RLock()
+	for _, s := range c.sessions {
+		if s.UID() == uid {
+			joinedAt, ok = c.joinTimes[s.sid]
+			break
+		}
+	}
+	c.mu.RUnlock()
+	if ok {
+		return joinedAt, true
+	}
+
+	m := c.membershipTable()
+	m.mu.Lock()
+	rec, found := m.records[uid]
+	m.mu.Unlock()
+	if found {
+		return rec.joinedAt, true
+	}
+
+	return time.Time{}, false
+}
+
 // Leave remove specified UID related session from group
 func (c *Group) Leave(s *Session) error {
 	if c.isClosed() {
@@ -150,9 +203,13 @@ func (c *Group) Leave(s *Session) error {
 	}
 
 	c.mu.Lock()
-	defer c.mu.Unlock()
-
 	delete(c.sessions, s.sid)
+	delete(c.joinTimes, s.sid)
+	c.untagAllLocked(s.sid)
+	c.mu.Unlock()
+
+	c.dropStats(s.sid)
+	c.emit(EventSessionLeft, s)
 	return nil
 }
 
@@ -166,6 +223,11 @@ func (c *Group) LeaveAll() error {
 	defer c.mu.Unlock()
 
 	c.sessions = make(map[int64]*Session)
+	c.joinTimes = make(map[facade.SID]time.Time)
+	c.tags = nil
+	c.sessionTags = nil
+	c.flushMembership()
+	c.clearStats()
 	return nil
 }
 
@@ -193,6 +255,16 @@ func (c *Group) Close() error {
 	atomic.StoreInt32(&c.status, groupStatusClosed)
 
 	// release all reference
+	c.mu.Lock()
 	c.sessions = make(map[int64]*Session)
+	c.joinTimes = make(map[facade.SID]time.Time)
+	c.tags = nil
+	c.sessionTags = nil
+	c.flushMembership()
+	c.clearStats()
+	c.mu.Unlock()
+
+	c.emit(EventGroupClosed, c.name)
+	c.EventEmitter.close()
 	return nil
-}
\ No newline at end of file
+}
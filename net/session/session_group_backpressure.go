@@ -0,0 +1,212 @@
+package cherrySession
+
+import (
+	facade "github.com/cherry-game/cherry/facade"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// defaultWarnPendingMessages is the default WarnPendingMessages value: a
+	// session that has this many pushes in flight at once gets a warning
+	// log, but is otherwise left alone.
+	defaultWarnPendingMessages = 64
+
+	// defaultMaxPendingMessages is the default MaxPendingMessages value: a
+	// session that exceeds this is treated as a slow consumer and removed
+	// from the group.
+	defaultMaxPendingMessages = 256
+
+	// defaultFanOutWorkers bounds how many sessions a single Broadcast/
+	// Multicast call pushes to concurrently, so one stalled socket cannot
+	// serialize delivery to the rest of the room.
+	defaultFanOutWorkers = 32
+
+	// defaultMaxConsecutiveFailures is the default MaxConsecutiveFailures
+	// value: a session whose Push fails this many times in a row is treated
+	// as a slow/broken consumer and removed, even if it never accumulates
+	// pending (eg. a closed socket that fails fast instead of blocking).
+	defaultMaxConsecutiveFailures = 5
+)
+
+// consumerStats tracks in-flight pushes and consecutive failures for a
+// single session, used to detect slow consumers without requiring changes
+// to Session itself.
+type consumerStats struct {
+	pending    int32 // pushes currently in flight to this session
+	failures   int32 // consecutive Push failures
+	slowWarned int32 // 1 once WarnPendingMessages has been logged, reset when pending drops back down
+}
+
+// GroupStats is a snapshot of a Group's fan-out activity, returned by
+// Group.Stats().
+type GroupStats struct {
+	TotalPushes   uint64 // successful Push calls across Broadcast/Multicast
+	TotalDrops    uint64 // Push calls that errored
+	SlowConsumers uint64 // sessions removed for exceeding MaxPendingMessages or MaxConsecutiveFailures
+}
+
+// groupBackpressure holds a Group's backpressure/fan-out config and counters.
+type groupBackpressure struct {
+	mu    sync.Mutex
+	stats map[facade.SID]*consumerStats
+
+	warnPendingMessages    int32
+	maxPendingMessages     int32
+	maxConsecutiveFailures int32
+	fanOutWorkers          int32
+
+	totalPushes   uint64
+	totalDrops    uint64
+	slowConsumers uint64
+}
+
+func newGroupBackpressure() *groupBackpressure {
+	return &groupBackpressure{
+		stats:                  make(map[facade.SID]*consumerStats),
+		warnPendingMessages:    defaultWarnPendingMessages,
+		maxPendingMessages:     defaultMaxPendingMessages,
+		maxConsecutiveFailures: defaultMaxConsecutiveFailures,
+		fanOutWorkers:          defaultFanOutWorkers,
+	}
+}
+
+// SetPendingLimits configures the warn/max pending-message thresholds used
+// to detect slow consumers. warn logs; max removes the session from the
+// group (and closes it).
+func (c *Group) SetPendingLimits(warn, max int) {
+	bp := c.backpressure()
+	atomic.StoreInt32(&bp.warnPendingMessages, int32(warn))
+	atomic.StoreInt32(&bp.maxPendingMessages, int32(max))
+}
+
+// SetMaxConsecutiveFailures configures how many consecutive Push failures a
+// session can accumulate before it is treated as a slow/broken consumer and
+// removed, independent of its pending count. The default is
+// defaultMaxConsecutiveFailures.
+func (c *Group) SetMaxConsecutiveFailures(n int) {
+	atomic.StoreInt32(&c.backpressure().maxConsecutiveFailures, int32(n))
+}
+
+// SetFanOutWorkers configures how many sessions Broadcast/Multicast push to
+// concurrently. The default is defaultFanOutWorkers.
+func (c *Group) SetFanOutWorkers(n int) {
+	if n < 1 {
+		n = 1
+	}
+	atomic.StoreInt32(&c.backpressure().fanOutWorkers, int32(n))
+}
+
+// Stats returns a snapshot of this group's fan-out counters.
+func (c *Group) Stats() GroupStats {
+	bp := c.backpressure()
+	return GroupStats{
+		TotalPushes:   atomic.LoadUint64(&bp.totalPushes),
+		TotalDrops:    atomic.LoadUint64(&bp.totalDrops),
+		SlowConsumers: atomic.LoadUint64(&bp.slowConsumers),
+	}
+}
+
+// backpressure returns c.bp. It is always non-nil: NewGroup allocates it
+// eagerly.
+func (c *Group) backpressure() *groupBackpressure {
+	return c.bp
+}
+
+// fanOut pushes route/v to every session in targets, parallelized across a
+// bounded worker pool so one stalled socket does not serialize delivery to
+// the rest of the room. Sessions that exceed MaxPendingMessages are removed
+// from the group as a side effect.
+func (c *Group) fanOut(targets []*Session, route string, v interface{}) {
+	bp := c.backpressure()
+
+	workers := int(atomic.LoadInt32(&bp.fanOutWorkers))
+	if workers > len(targets) {
+		workers = len(targets)
+	}
+	if workers < 1 {
+		return
+	}
+
+	jobs := make(chan *Session, len(targets))
+	for _, s := range targets {
+		jobs <- s
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for s := range jobs {
+				c.pushToSession(bp, s, route, v)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// pushToSession performs one Push, tracking pending/failure counters and
+// enforcing WarnPendingMessages/MaxPendingMessages.
+func (c *Group) pushToSession(bp *groupBackpressure, s *Session, route string, v interface{}) {
+	bp.mu.Lock()
+	stats, ok := bp.stats[s.sid]
+	if !ok {
+		stats = &consumerStats{}
+		bp.stats[s.sid] = stats
+	}
+	bp.mu.Unlock()
+
+	pending := atomic.AddInt32(&stats.pending, 1)
+	defer atomic.AddInt32(&stats.pending, -1)
+
+	warn := atomic.LoadInt32(&bp.warnPendingMessages)
+	max := atomic.LoadInt32(&bp.maxPendingMessages)
+	maxFailures := atomic.LoadInt32(&bp.maxConsecutiveFailures)
+	failures := atomic.LoadInt32(&stats.failures)
+
+	if pending > max || failures >= maxFailures {
+		atomic.AddUint64(&bp.slowConsumers, 1)
+		s.Warnf("slow consumer, leaving group[%s], SID[%d], UID[%d], pending[%d], failures[%d]",
+			c.name, s.SID(), s.UID(), pending, failures)
+		_ = c.Leave(s)
+		_ = s.Close()
+		return
+	}
+
+	if pending >= warn && atomic.CompareAndSwapInt32(&stats.slowWarned, 0, 1) {
+		s.Warnf("pending messages threshold exceeded, SID[%d], UID[%d], pending[%d]", s.SID(), s.UID(), pending)
+	} else if pending < warn {
+		atomic.StoreInt32(&stats.slowWarned, 0)
+	}
+
+	err := s.Push(route, v)
+	if err != nil {
+		atomic.AddInt32(&stats.failures, 1)
+		atomic.AddUint64(&bp.totalDrops, 1)
+		s.Warnf("push message error, SID[%d], UID[%d], Error[%s]", s.SID(), s.UID(), err.Error())
+		c.emit(EventPushError, s, route, err)
+		return
+	}
+
+	atomic.StoreInt32(&stats.failures, 0)
+	atomic.AddUint64(&bp.totalPushes, 1)
+}
+
+// dropStats removes any tracked backpressure counters for id. Called on
+// Leave/Disconnect so a reused SID does not inherit stale state.
+func (c *Group) dropStats(id facade.SID) {
+	c.bp.mu.Lock()
+	delete(c.bp.stats, id)
+	c.bp.mu.Unlock()
+}
+
+// clearStats discards every tracked backpressure counter. Called on
+// LeaveAll/Close so a group reused across rounds (eg. a game room) does not
+// leak one consumerStats per historical session.
+func (c *Group) clearStats() {
+	c.bp.mu.Lock()
+	c.bp.stats = make(map[facade.SID]*consumerStats)
+	c.bp.mu.Unlock()
+}
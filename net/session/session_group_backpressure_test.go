@@ -0,0 +1,51 @@
+package cherrySession
+
+import (
+	"testing"
+
+	facade "github.com/cherry-game/cherry/facade"
+)
+
+func TestClearStatsResetsTrackedSessions(t *testing.T) {
+	g := NewGroup("room")
+
+	sid := facade.SID(1)
+	g.bp.stats[sid] = &consumerStats{pending: 3, failures: 2}
+
+	g.clearStats()
+
+	if len(g.bp.stats) != 0 {
+		t.Fatalf("expected stats to be cleared, got %+v", g.bp.stats)
+	}
+}
+
+func TestDropStatsRemovesSingleSession(t *testing.T) {
+	g := NewGroup("room")
+
+	sidA := facade.SID(1)
+	sidB := facade.SID(2)
+	g.bp.stats[sidA] = &consumerStats{}
+	g.bp.stats[sidB] = &consumerStats{}
+
+	g.dropStats(sidA)
+
+	if _, ok := g.bp.stats[sidA]; ok {
+		t.Fatalf("expected sidA to be dropped")
+	}
+	if _, ok := g.bp.stats[sidB]; !ok {
+		t.Fatalf("expected sidB to be left untouched")
+	}
+}
+
+func TestLeaveAllClearsBackpressureStats(t *testing.T) {
+	g := NewGroup("room")
+	g.bp.stats[facade.SID(1)] = &consumerStats{pending: 5}
+
+	if err := g.LeaveAll(); err != nil {
+		t.Fatalf("unexpected error from LeaveAll: %s", err.Error())
+	}
+
+	if len(g.bp.stats) != 0 {
+		t.Fatalf("expected LeaveAll to clear backpressure stats, got %+v", g.bp.stats)
+	}
+}
@@ -0,0 +1,380 @@
+package cherrySession
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cherry-game/cherry/error"
+	facade "github.com/cherry-game/cherry/facade"
+	"github.com/cherry-game/cherry/logger"
+)
+
+// ClusterTransport is the pluggable fan-out backend used by ClusterGroup to
+// replicate group operations (Broadcast/Multicast/Add/Leave) across nodes.
+// NewNatsTransport provides a NATS-backed implementation; any other backend
+// (eg. Redis streams) only needs to satisfy this interface to be swapped in.
+type ClusterTransport interface {
+	// Publish sends data to subject, fire-and-forget.
+	Publish(subject string, data []byte) error
+	// Subscribe registers a queue-less subscription on subject, so every
+	// node in the cluster observes every published message. handler's reply
+	// func is non-nil only when the incoming message expects a response
+	// (ie. it arrived via Request on another node); it is nil otherwise.
+	Subscribe(subject string, handler func(data []byte, reply func(data []byte))) (unsubscribe func(), err error)
+	// Request performs a request/reply call with timeout, used to aggregate
+	// Member/Members/Count across nodes. It returns one reply per responder
+	// that answered before timeout elapses.
+	Request(subject string, data []byte, timeout time.Duration) ([][]byte, error)
+}
+
+// clusterEnvelopeKind identifies the operation carried by a clusterEnvelope.
+type clusterEnvelopeKind string
+
+const (
+	clusterEnvelopeJoin    clusterEnvelopeKind = "join"
+	clusterEnvelopeLeave   clusterEnvelopeKind = "leave"
+	clusterEnvelopePush    clusterEnvelopeKind = "push"
+	clusterEnvelopeQuery   clusterEnvelopeKind = "query"
+	clusterRequestTimeout                      = 3 * time.Second
+	clusterPublishMaxRetry                     = 3
+)
+
+// clusterEnvelope is the wire format published to the group's NATS subjects.
+// A SessionFilter cannot be serialized, so Multicast envelopes carry the
+// registered filter name plus an opaque arg blob instead of a closure.
+type clusterEnvelope struct {
+	Kind       clusterEnvelopeKind `json:"kind"`
+	Route      string              `json:"route,omitempty"`
+	Data       json.RawMessage     `json:"data,omitempty"`
+	FilterName string              `json:"filterName,omitempty"`
+	FilterArg  json.RawMessage     `json:"filterArg,omitempty"`
+	SID        facade.SID          `json:"sid,omitempty"`
+	UID        int64               `json:"uid,omitempty"`
+}
+
+// clusterQueryReply is returned by each node in response to a Member/Members/
+// Count query envelope.
+type clusterQueryReply struct {
+	UIDs []int64 `json:"uids"`
+}
+
+// namedClusterFilter is a SessionFilter that can be referenced by name across
+// the wire. Register one with RegisterClusterFilter before using it with
+// ClusterGroup.Multicast.
+type namedClusterFilter func(s *Session, arg json.RawMessage) bool
+
+var (
+	clusterFilterMu  sync.RWMutex
+	clusterFilterMap = make(map[string]namedClusterFilter)
+)
+
+// RegisterClusterFilter registers a named filter usable with
+// ClusterGroup.Multicast. arg is the raw JSON blob passed to Multicast and is
+// forwarded verbatim to every node so fn can be re-evaluated locally there.
+func RegisterClusterFilter(name string, fn func(s *Session, arg json.RawMessage) bool) {
+	clusterFilterMu.Lock()
+	defer clusterFilterMu.Unlock()
+	clusterFilterMap[name] = fn
+}
+
+func getClusterFilter(name string) (namedClusterFilter, bool) {
+	clusterFilterMu.RLock()
+	defer clusterFilterMu.RUnlock()
+	fn, ok := clusterFilterMap[name]
+	return fn, ok
+}
+
+// ClusterGroup wraps a local Group and transparently fans Broadcast,
+// Multicast, Add and Leave out to every other node subscribed to the same
+// group name, using transport (eg. NewNatsTransport) as the backbone.
+type ClusterGroup struct {
+	*Group
+
+	transport   ClusterTransport
+	subject     string
+	querySubj   string
+	unsubscribe []func()
+}
+
+// NewClusterGroup returns a ClusterGroup named n, backed by transport. It
+// subscribes immediately to two subjects: the group subject, which carries
+// push, join and leave envelopes alike, and the group's query subject, so
+// Broadcast/Multicast/Add/Leave issued on any node (including this one) are
+// observed and applied locally here too.
+func NewClusterGroup(transport ClusterTransport, n string) (*ClusterGroup, error) {
+	cg := &ClusterGroup{
+		Group:     NewGroup(n),
+		transport: transport,
+		subject:   fmt.Sprintf("cherry.group.%s", n),
+		querySubj: fmt.Sprintf("cherry.group.%s.query", n),
+	}
+
+	unsubPush, err := transport.Subscribe(cg.subject, cg.onEnvelope)
+	if err != nil {
+		return nil, err
+	}
+	cg.unsubscribe = append(cg.unsubscribe, unsubPush)
+
+	unsubQuery, err := transport.Subscribe(cg.querySubj, cg.onQuery)
+	if err != nil {
+		unsubPush()
+		return nil, err
+	}
+	cg.unsubscribe = append(cg.unsubscribe, unsubQuery)
+
+	return cg, nil
+}
+
+// Broadcast publishes route/v to every node in the cluster; each node
+// (including this one) applies it to its own local sessions on receipt.
+func (cg *ClusterGroup) Broadcast(route string, v interface{}) error {
+	if cg.isClosed() {
+		return cherryError.SessionClosedGroup
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	env := clusterEnvelope{
+		Kind:  clusterEnvelopePush,
+		Route: route,
+		Data:  data,
+	}
+	return cg.publish(env)
+}
+
+// Multicast publishes route/v to every node in the cluster, scoped to the
+// named filter registered via RegisterClusterFilter. Each node decodes arg
+// and re-evaluates the filter against its own local sessions.
+func (cg *ClusterGroup) Multicast(route string, v interface{}, filterName string, arg interface{}) error {
+	if cg.isClosed() {
+		return cherryError.SessionClosedGroup
+	}
+
+	if _, ok := getClusterFilter(filterName); !ok {
+		return fmt.Errorf("cluster filter not registered, name[%s]", filterName)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	argData, err := json.Marshal(arg)
+	if err != nil {
+		return err
+	}
+
+	env := clusterEnvelope{
+		Kind:       clusterEnvelopePush,
+		Route:      route,
+		Data:       data,
+		FilterName: filterName,
+		FilterArg:  argData,
+	}
+	return cg.publish(env)
+}
+
+// Add adds session to the local group and announces the join to every other
+// node. Receiving nodes do not act on the announcement: HasMember, Members
+// and Count query each node's local group directly.
+func (cg *ClusterGroup) Add(session *Session) error {
+	if err := cg.Group.Add(session); err != nil {
+		return err
+	}
+
+	env := clusterEnvelope{
+		Kind: clusterEnvelopeJoin,
+		SID:  session.sid,
+		UID:  session.UID(),
+	}
+	return cg.publish(env)
+}
+
+// Leave removes s from the local group and announces the departure to every
+// other node.
+func (cg *ClusterGroup) Leave(s *Session) error {
+	if err := cg.Group.Leave(s); err != nil {
+		return err
+	}
+
+	env := clusterEnvelope{
+		Kind: clusterEnvelopeLeave,
+		SID:  s.sid,
+		UID:  s.UID(),
+	}
+	return cg.publish(env)
+}
+
+// HasMember aggregates membership across the cluster: if the UID is not
+// present in the local group, every other node is asked via request/reply.
+// It intentionally does not override Group.Member, whose (*Session, error)
+// contract cannot be honored for a UID that only exists on another node.
+func (cg *ClusterGroup) HasMember(uid int64) (bool, error) {
+	if cg.Group.Contains(uid) {
+		return true, nil
+	}
+
+	req := clusterEnvelope{Kind: clusterEnvelopeQuery, UID: uid}
+	data, err := json.Marshal(req)
+	if err != nil {
+		return false, err
+	}
+
+	replies, err := cg.transport.Request(cg.querySubj, data, clusterRequestTimeout)
+	if err != nil {
+		return false, err
+	}
+
+	for _, raw := range replies {
+		var reply clusterQueryReply
+		if err := json.Unmarshal(raw, &reply); err != nil {
+			continue
+		}
+		for _, u := range reply.UIDs {
+			if u == uid {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// Members aggregates Members across the cluster by merging the local group
+// with every other node's reply to a query broadcast.
+func (cg *ClusterGroup) Members() []int64 {
+	members := cg.Group.Members()
+
+	data, err := json.Marshal(clusterEnvelope{Kind: clusterEnvelopeQuery})
+	if err != nil {
+		return members
+	}
+
+	replies, err := cg.transport.Request(cg.querySubj, data, clusterRequestTimeout)
+	if err != nil {
+		cherryLogger.Warnf("cluster members query failed, group[%s], error[%s]", cg.name, err.Error())
+		return members
+	}
+
+	seen := make(map[int64]bool, len(members))
+	for _, uid := range members {
+		seen[uid] = true
+	}
+
+	for _, raw := range replies {
+		var reply clusterQueryReply
+		if err := json.Unmarshal(raw, &reply); err != nil {
+			continue
+		}
+		for _, uid := range reply.UIDs {
+			if !seen[uid] {
+				seen[uid] = true
+				members = append(members, uid)
+			}
+		}
+	}
+
+	return members
+}
+
+// Count aggregates Count across the cluster.
+func (cg *ClusterGroup) Count() int {
+	return len(cg.Members())
+}
+
+// Close releases local resources and unsubscribes from every cluster
+// subject this group registered.
+func (cg *ClusterGroup) Close() error {
+	for _, unsub := range cg.unsubscribe {
+		unsub()
+	}
+	return cg.Group.Close()
+}
+
+// publish tries Publish up to clusterPublishMaxRetry times in all, so a
+// transient transport error does not silently drop a Broadcast/Multicast.
+func (cg *ClusterGroup) publish(env clusterEnvelope) error {
+	data, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	for i := 0; i < clusterPublishMaxRetry; i++ {
+		if lastErr = cg.transport.Publish(cg.subject, data); lastErr == nil {
+			return nil
+		}
+		cherryLogger.Warnf("cluster publish failed, group[%s], kind[%s], attempt[%d], error[%s]",
+			cg.name, env.Kind, i+1, lastErr.Error())
+	}
+
+	return lastErr
+}
+
+// onEnvelope is invoked on every node (including the publisher) whenever a
+// message is received on the group's push subject.
+func (cg *ClusterGroup) onEnvelope(data []byte, _ func([]byte)) {
+	var env clusterEnvelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		cherryLogger.Warnf("cluster envelope decode error, group[%s], error[%s]", cg.name, err.Error())
+		return
+	}
+
+	switch env.Kind {
+	case clusterEnvelopePush:
+		cg.applyPush(env)
+	case clusterEnvelopeJoin, clusterEnvelopeLeave:
+		// membership is only mutated by the node that owns the session;
+		// other nodes merely observe it via Member/Members queries.
+	}
+}
+
+func (cg *ClusterGroup) applyPush(env clusterEnvelope) {
+	var filter namedClusterFilter
+	if env.FilterName != "" {
+		fn, ok := getClusterFilter(env.FilterName)
+		if !ok {
+			cherryLogger.Warnf("cluster filter not registered locally, group[%s], name[%s]", cg.name, env.FilterName)
+			return
+		}
+		filter = fn
+	}
+
+	cg.mu.RLock()
+	targets := make([]*Session, 0, len(cg.sessions))
+	for _, s := range cg.sessions {
+		if filter == nil || filter(s, env.FilterArg) {
+			targets = append(targets, s)
+		}
+	}
+	cg.mu.RUnlock()
+
+	var v json.RawMessage = env.Data
+	cg.fanOut(targets, env.Route, v)
+
+	if env.FilterName != "" {
+		cg.emit(EventMulticastSent, env.Route, v)
+	} else {
+		cg.emit(EventBroadcastSent, env.Route, v)
+	}
+}
+
+// onQuery answers a Member/Members query with the UIDs currently held
+// locally, via the transport-supplied reply func.
+func (cg *ClusterGroup) onQuery(_ []byte, reply func([]byte)) {
+	if reply == nil {
+		return
+	}
+
+	data, err := json.Marshal(clusterQueryReply{UIDs: cg.Group.Members()})
+	if err != nil {
+		return
+	}
+
+	reply(data)
+}
@@ -0,0 +1,122 @@
+package cherrySession
+
+import (
+	"sync"
+
+	"github.com/cherry-game/cherry/logger"
+)
+
+// event names fired by Group's EventEmitter.
+const (
+	EventSessionAdded  = "session.added"
+	EventSessionLeft   = "session.left"
+	EventGroupClosed   = "group.closed"
+	EventBroadcastSent = "broadcast.sent"
+	EventMulticastSent = "multicast.sent"
+	EventPushError     = "push.error"
+
+	// eventQueueSize bounds the per-group dispatch channel, so a slow
+	// subscriber cannot block Push by backing up the emitter goroutine.
+	eventQueueSize = 256
+)
+
+// eventHandler is the callback signature accepted by Group.On.
+type eventHandler func(args ...interface{})
+
+// EventEmitter lets plugins observe a Group's lifecycle without patching
+// Group itself. Events dispatch on a dedicated goroutine, outside the
+// Group's RWMutex, so a slow handler cannot stall Push.
+type EventEmitter struct {
+	mu       sync.RWMutex
+	handlers map[string][]eventHandler
+	queue    chan emittedEvent
+	done     chan struct{}
+	once     sync.Once
+}
+
+type emittedEvent struct {
+	name string
+	args []interface{}
+}
+
+// newEventEmitter returns a ready-to-use EventEmitter with its dispatch loop
+// already running.
+func newEventEmitter() *EventEmitter {
+	e := &EventEmitter{
+		handlers: make(map[string][]eventHandler),
+		queue:    make(chan emittedEvent, eventQueueSize),
+		done:     make(chan struct{}),
+	}
+
+	go e.dispatchLoop()
+	return e
+}
+
+// On registers handler to be invoked whenever event fires.
+func (e *EventEmitter) On(event string, handler func(args ...interface{})) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.handlers[event] = append(e.handlers[event], handler)
+}
+
+// Off removes every handler registered for event.
+func (e *EventEmitter) Off(event string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	delete(e.handlers, event)
+}
+
+// emit enqueues event for asynchronous dispatch, dropping (and logging) it
+// if the queue is full rather than blocking the caller.
+func (e *EventEmitter) emit(event string, args ...interface{}) {
+	select {
+	case e.queue <- emittedEvent{name: event, args: args}:
+	default:
+		cherryLogger.Warnf("event emitter queue full, event[%s] dropped", event)
+	}
+}
+
+func (e *EventEmitter) dispatchLoop() {
+	for {
+		select {
+		case evt := <-e.queue:
+			e.dispatch(evt)
+		case <-e.done:
+			// queue and done can both be ready; drain so a pending event isn't dropped.
+			e.drainQueue()
+			return
+		}
+	}
+}
+
+func (e *EventEmitter) dispatch(evt emittedEvent) {
+	e.mu.RLock()
+	handlers := append([]eventHandler(nil), e.handlers[evt.name]...)
+	e.mu.RUnlock()
+
+	for _, h := range handlers {
+		h(evt.args...)
+	}
+}
+
+// drainQueue dispatches whatever is already buffered in e.queue, non-
+// blocking. Called once from dispatchLoop right before it returns.
+func (e *EventEmitter) drainQueue() {
+	for {
+		select {
+		case evt := <-e.queue:
+			e.dispatch(evt)
+		default:
+			return
+		}
+	}
+}
+
+// close stops the dispatch loop. Safe to call more than once.
+func (e *EventEmitter) close() {
+	e.once.Do(func() {
+		close(e.done)
+	})
+}
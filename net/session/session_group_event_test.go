@@ -0,0 +1,53 @@
+package cherrySession
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestEventEmitterConcurrentEmitAndCloseDoesNotPanic(t *testing.T) {
+	e := newEventEmitter()
+	e.On(EventBroadcastSent, func(args ...interface{}) {})
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			e.emit(EventBroadcastSent, "route", i)
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		e.close()
+	}()
+
+	wg.Wait()
+
+	// emitting after close must still not panic.
+	e.emit(EventBroadcastSent, "route", "late")
+}
+
+func TestEventEmitterDeliversEventEmittedRightBeforeClose(t *testing.T) {
+	e := newEventEmitter()
+
+	received := make(chan interface{}, 1)
+	e.On(EventGroupClosed, func(args ...interface{}) {
+		received <- args[0]
+	})
+
+	e.emit(EventGroupClosed, "room")
+	e.close()
+
+	select {
+	case name := <-received:
+		if name != "room" {
+			t.Fatalf("expected event arg %q, got %v", "room", name)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("event emitted before close was never delivered")
+	}
+}
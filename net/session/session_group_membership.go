@@ -0,0 +1,173 @@
+package cherrySession
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cherry-game/cherry/error"
+)
+
+// defaultRejoinTTL is how long a disconnected UID's membership (and tags)
+// are kept around for Rejoin, following the session-expire pattern used by
+// standalone signaling servers.
+const defaultRejoinTTL = 30 * time.Second
+
+// membershipRecord is a suspended membership kept around after Disconnect.
+type membershipRecord struct {
+	uid      int64
+	tags     map[string]string
+	joinedAt time.Time // original Add time, carried over from Group.joinTimes
+	timer    *time.Timer
+}
+
+// groupMembership is the UID-indexed membership table backing
+// Disconnect/Rejoin/OnExpire.
+type groupMembership struct {
+	mu       sync.Mutex
+	ttl      time.Duration
+	records  map[int64]*membershipRecord
+	onExpire func(uid int64)
+}
+
+func newGroupMembership() *groupMembership {
+	return &groupMembership{
+		ttl:     defaultRejoinTTL,
+		records: make(map[int64]*membershipRecord),
+	}
+}
+
+// membershipTable returns c.membership, which NewGroup always allocates.
+func (c *Group) membershipTable() *groupMembership {
+	return c.membership
+}
+
+// SetRejoinTTL configures how long a UID's membership survives Disconnect
+// before OnExpire fires and the record is dropped. The default is
+// defaultRejoinTTL.
+func (c *Group) SetRejoinTTL(ttl time.Duration) {
+	m := c.membershipTable()
+
+	m.mu.Lock()
+	m.ttl = ttl
+	m.mu.Unlock()
+}
+
+// OnExpire registers fn to be called with a UID once its suspended
+// membership's TTL elapses without a Rejoin. Use this to react to real
+// departures (eg. remove a player's pawn from a room) rather than to every
+// Disconnect, which may just be a transient network blip.
+func (c *Group) OnExpire(fn func(uid int64)) {
+	m := c.membershipTable()
+
+	m.mu.Lock()
+	m.onExpire = fn
+	m.mu.Unlock()
+}
+
+// Disconnect removes s from the group's active sessions, like Leave, but
+// suspends its UID's membership (and tags) for RejoinTTL instead of
+// forgetting it immediately. Call this for an unexpected disconnect; call
+// Leave for a deliberate departure, which drops membership right away.
+func (c *Group) Disconnect(s *Session) error {
+	if c.isClosed() {
+		return cherryError.SessionClosedGroup
+	}
+
+	c.mu.Lock()
+	delete(c.sessions, s.sid)
+	joinedAt := c.joinTimes[s.sid]
+	delete(c.joinTimes, s.sid)
+	tags := c.snapshotTagsLocked(s.sid)
+	c.untagAllLocked(s.sid)
+	c.mu.Unlock()
+
+	c.dropStats(s.sid)
+	c.suspend(s.UID(), tags, joinedAt)
+	c.emit(EventSessionLeft, s)
+	return nil
+}
+
+// Rejoin re-attaches session to the group under its prior UID, restoring
+// the tags it held before Disconnect and cancelling the pending expiry. If
+// no suspended membership is found (TTL already elapsed, or the UID was
+// never suspended) session is simply added as new.
+func (c *Group) Rejoin(session *Session) error {
+	m := c.membershipTable()
+
+	m.mu.Lock()
+	rec, ok := m.records[session.UID()]
+	if ok {
+		rec.timer.Stop()
+		delete(m.records, session.UID())
+	}
+	m.mu.Unlock()
+
+	if err := c.Add(session); err != nil {
+		return err
+	}
+
+	if ok {
+		c.mu.Lock()
+		c.joinTimes[session.sid] = rec.joinedAt
+		c.mu.Unlock()
+
+		for key, value := range rec.tags {
+			_ = c.Tag(session, key, value)
+		}
+	}
+
+	return nil
+}
+
+// suspend records uid as disconnected-but-pending-expiry, starting (or
+// restarting) its TTL timer. joinedAt is the UID's original join time,
+// carried over so Rejoin can restore it.
+func (c *Group) suspend(uid int64, tags map[string]string, joinedAt time.Time) {
+	m := c.membershipTable()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rec, ok := m.records[uid]; ok {
+		rec.timer.Stop()
+	}
+
+	rec := &membershipRecord{
+		uid:      uid,
+		tags:     tags,
+		joinedAt: joinedAt,
+	}
+	rec.timer = time.AfterFunc(m.ttl, func() {
+		c.expire(uid)
+	})
+	m.records[uid] = rec
+}
+
+// expire drops uid's suspended membership and fires OnExpire, unless it was
+// already removed by a Rejoin that raced with the timer.
+func (c *Group) expire(uid int64) {
+	m := c.membershipTable()
+
+	m.mu.Lock()
+	_, ok := m.records[uid]
+	delete(m.records, uid)
+	onExpire := m.onExpire
+	m.mu.Unlock()
+
+	if ok && onExpire != nil {
+		onExpire(uid)
+	}
+}
+
+// flushMembership stops every pending expiry timer and clears the
+// membership table, without firing OnExpire: Close/LeaveAll tear the whole
+// group down, which is not the "real departure" OnExpire is meant to signal.
+func (c *Group) flushMembership() {
+	m := c.membership
+	m.mu.Lock()
+	for _, rec := range m.records {
+		rec.timer.Stop()
+	}
+	m.records = make(map[int64]*membershipRecord)
+	m.mu.Unlock()
+}
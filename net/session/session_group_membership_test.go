@@ -0,0 +1,113 @@
+package cherrySession
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSuspendFiresOnExpireAfterTTL(t *testing.T) {
+	g := NewGroup("room")
+	g.SetRejoinTTL(20 * time.Millisecond)
+
+	expired := make(chan int64, 1)
+	g.OnExpire(func(uid int64) {
+		expired <- uid
+	})
+
+	g.suspend(42, map[string]string{"region": "eu"}, time.Now())
+
+	select {
+	case uid := <-expired:
+		if uid != 42 {
+			t.Fatalf("expected uid 42, got %d", uid)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for OnExpire")
+	}
+
+	m := g.membershipTable()
+	m.mu.Lock()
+	_, ok := m.records[42]
+	m.mu.Unlock()
+	if ok {
+		t.Fatal("expected expired record to be removed from the membership table")
+	}
+}
+
+func TestCancelledSuspensionDoesNotFireOnExpire(t *testing.T) {
+	g := NewGroup("room")
+	g.SetRejoinTTL(50 * time.Millisecond)
+
+	fired := false
+	g.OnExpire(func(uid int64) {
+		fired = true
+	})
+
+	g.suspend(7, map[string]string{"region": "eu"}, time.Now())
+
+	m := g.membershipTable()
+	m.mu.Lock()
+	rec, ok := m.records[7]
+	if !ok {
+		m.mu.Unlock()
+		t.Fatal("expected suspended record to exist")
+	}
+	rec.timer.Stop()
+	delete(m.records, 7)
+	m.mu.Unlock()
+
+	time.Sleep(100 * time.Millisecond)
+	if fired {
+		t.Fatal("did not expect OnExpire to fire once the record was cancelled")
+	}
+}
+
+func TestFlushMembershipStopsTimersWithoutFiringOnExpire(t *testing.T) {
+	g := NewGroup("room")
+	g.SetRejoinTTL(20 * time.Millisecond)
+
+	fired := false
+	g.OnExpire(func(uid int64) {
+		fired = true
+	})
+
+	g.suspend(1, nil, time.Now())
+	g.flushMembership()
+
+	time.Sleep(50 * time.Millisecond)
+	if fired {
+		t.Fatal("flushMembership should not trigger OnExpire")
+	}
+
+	m := g.membershipTable()
+	m.mu.Lock()
+	count := len(m.records)
+	m.mu.Unlock()
+	if count != 0 {
+		t.Fatalf("expected membership table to be empty after flush, got %d records", count)
+	}
+}
+
+func TestJoinedAtReturnsSuspendedMembersOriginalJoinTime(t *testing.T) {
+	g := NewGroup("room")
+	g.SetRejoinTTL(time.Minute)
+
+	want := time.Now().Add(-time.Hour)
+	g.suspend(99, nil, want)
+
+	got, ok := g.JoinedAt(99)
+	if !ok {
+		t.Fatal("expected JoinedAt to find the suspended membership")
+	}
+	if !got.Equal(want) {
+		t.Fatalf("expected joinedAt %v, got %v", want, got)
+	}
+}
+
+func TestJoinedAtUnknownUIDNotFound(t *testing.T) {
+	g := NewGroup("room")
+
+	if _, ok := g.JoinedAt(123); ok {
+		t.Fatal("expected ok=false for a uid that was never a member")
+	}
+}
@@ -0,0 +1,203 @@
+package cherrySession
+
+import (
+	"github.com/cherry-game/cherry/error"
+	facade "github.com/cherry-game/cherry/facade"
+	"github.com/cherry-game/cherry/logger"
+	"github.com/cherry-game/cherry/profile"
+)
+
+// Tag attaches key=value to session within the group, and indexes it so
+// MulticastByTag/MulticastByTags can reach it in O(matching sessions)
+// instead of scanning the whole group. Prefer this over a SessionFilter for
+// region/country/level-style routing in large rooms; SessionFilter remains
+// available for arbitrary predicates that cannot be expressed as tags.
+func (c *Group) Tag(session *Session, key, value string) error {
+	if c.isClosed() {
+		return cherryError.SessionClosedGroup
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id := session.sid
+	if _, ok := c.sessions[id]; !ok {
+		return cherryError.SessionMemberNotFound
+	}
+
+	if c.tags == nil {
+		c.tags = make(map[string]map[string]map[facade.SID]*Session)
+	}
+	if c.sessionTags == nil {
+		c.sessionTags = make(map[facade.SID]map[string]string)
+	}
+
+	c.untagLocked(id, key)
+
+	byValue, ok := c.tags[key]
+	if !ok {
+		byValue = make(map[string]map[facade.SID]*Session)
+		c.tags[key] = byValue
+	}
+	bySID, ok := byValue[value]
+	if !ok {
+		bySID = make(map[facade.SID]*Session)
+		byValue[value] = bySID
+	}
+	bySID[id] = session
+
+	values, ok := c.sessionTags[id]
+	if !ok {
+		values = make(map[string]string)
+		c.sessionTags[id] = values
+	}
+	values[key] = value
+
+	return nil
+}
+
+// Untag removes key from session, if present.
+func (c *Group) Untag(session *Session, key string) error {
+	if c.isClosed() {
+		return cherryError.SessionClosedGroup
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.untagLocked(session.sid, key)
+	return nil
+}
+
+// untagLocked removes id's value for key from the tag index. Callers must
+// hold c.mu.
+func (c *Group) untagLocked(id facade.SID, key string) {
+	values, ok := c.sessionTags[id]
+	if !ok {
+		return
+	}
+
+	oldValue, ok := values[key]
+	if !ok {
+		return
+	}
+
+	delete(values, key)
+	if len(values) == 0 {
+		delete(c.sessionTags, id)
+	}
+
+	if byValue, ok := c.tags[key]; ok {
+		if bySID, ok := byValue[oldValue]; ok {
+			delete(bySID, id)
+			if len(bySID) == 0 {
+				delete(byValue, oldValue)
+			}
+		}
+		if len(byValue) == 0 {
+			delete(c.tags, key)
+		}
+	}
+}
+
+// untagAllLocked removes every tag held by id. Callers must hold c.mu.
+func (c *Group) untagAllLocked(id facade.SID) {
+	values := c.sessionTags[id]
+	for key := range values {
+		c.untagLocked(id, key)
+	}
+}
+
+// snapshotTagsLocked returns a copy of the tags held by id, or nil if it has
+// none. Callers must hold at least c.mu.RLock().
+func (c *Group) snapshotTagsLocked(id facade.SID) map[string]string {
+	values := c.sessionTags[id]
+	if len(values) == 0 {
+		return nil
+	}
+
+	snapshot := make(map[string]string, len(values))
+	for k, v := range values {
+		snapshot[k] = v
+	}
+	return snapshot
+}
+
+// MulticastByTag pushes route/v to every session tagged key=value. This is
+// the fast lane for region/country/level-based pushes in large rooms: it
+// costs O(matching sessions), not O(group size) like Multicast with a
+// SessionFilter.
+func (c *Group) MulticastByTag(route string, v interface{}, key, value string) error {
+	return c.MulticastByTags(route, v, map[string]string{key: value})
+}
+
+// MulticastByTags pushes route/v to every session that matches all key/value
+// pairs in tags. An empty tags map pushes to no session.
+func (c *Group) MulticastByTags(route string, v interface{}, tags map[string]string) error {
+	if c.isClosed() {
+		return cherryError.SessionClosedGroup
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+
+	if cherryProfile.Debug() {
+		cherryLogger.Debugf("multicastByTags[%s], tags[%+v], Data[%+v]", route, tags, v)
+	}
+
+	c.mu.RLock()
+	matched := c.matchByTagsLocked(tags)
+	targets := make([]*Session, 0, len(matched))
+	for _, s := range matched {
+		targets = append(targets, s)
+	}
+	c.mu.RUnlock()
+
+	c.fanOut(targets, route, v)
+
+	c.emit(EventMulticastSent, route, v)
+	return nil
+}
+
+// matchByTagsLocked returns every session matching all key/value pairs in
+// tags, seeded from the smallest matching value-bucket. Callers must hold at
+// least c.mu.RLock().
+func (c *Group) matchByTagsLocked(tags map[string]string) map[facade.SID]*Session {
+	var smallestKey, smallestValue string
+	var smallest map[facade.SID]*Session
+
+	for key, value := range tags {
+		bucket := c.tags[key][value]
+		if len(bucket) == 0 {
+			return nil
+		}
+		if smallest == nil || len(bucket) < len(smallest) {
+			smallest, smallestKey, smallestValue = bucket, key, value
+		}
+	}
+
+	matches := make(map[facade.SID]*Session, len(smallest))
+	for id, s := range smallest {
+		if c.matchesAllTagsLocked(id, tags, smallestKey, smallestValue) {
+			matches[id] = s
+		}
+	}
+
+	return matches
+}
+
+// matchesAllTagsLocked reports whether id carries every key/value pair in
+// tags. skipKey/skipValue identify the bucket id was already sourced from,
+// so it is not re-checked.
+func (c *Group) matchesAllTagsLocked(id facade.SID, tags map[string]string, skipKey, skipValue string) bool {
+	for key, value := range tags {
+		if key == skipKey && value == skipValue {
+			continue
+		}
+		if c.sessionTags[id][key] != value {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package cherrySession
+
+import (
+	"testing"
+
+	facade "github.com/cherry-game/cherry/facade"
+)
+
+func TestMatchByTagsLockedRequiresAllPairs(t *testing.T) {
+	g := NewGroup("room")
+
+	sidA := facade.SID(1)
+	sidB := facade.SID(2)
+	sidC := facade.SID(3)
+
+	g.tags = map[string]map[string]map[facade.SID]*Session{
+		"region": {
+			"eu": {sidA: nil, sidB: nil},
+			"us": {sidC: nil},
+		},
+		"level": {
+			"10": {sidA: nil, sidC: nil},
+		},
+	}
+	g.sessionTags = map[facade.SID]map[string]string{
+		sidA: {"region": "eu", "level": "10"},
+		sidB: {"region": "eu"},
+		sidC: {"region": "us", "level": "10"},
+	}
+
+	matches := g.matchByTagsLocked(map[string]string{"region": "eu", "level": "10"})
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(matches))
+	}
+	if _, ok := matches[sidA]; !ok {
+		t.Fatalf("expected sidA to be the only match")
+	}
+}
+
+func TestMatchByTagsLockedNoMatch(t *testing.T) {
+	g := NewGroup("room")
+
+	g.tags = map[string]map[string]map[facade.SID]*Session{
+		"region": {"eu": {facade.SID(1): nil}},
+	}
+	g.sessionTags = map[facade.SID]map[string]string{
+		facade.SID(1): {"region": "eu"},
+	}
+
+	matches := g.matchByTagsLocked(map[string]string{"region": "apac"})
+	if len(matches) != 0 {
+		t.Fatalf("expected no matches, got %d", len(matches))
+	}
+}
+
+func TestUntagLockedPrunesEmptyBuckets(t *testing.T) {
+	g := NewGroup("room")
+
+	sidA := facade.SID(1)
+	g.tags = map[string]map[string]map[facade.SID]*Session{
+		"region": {"eu": {sidA: nil}},
+	}
+	g.sessionTags = map[facade.SID]map[string]string{
+		sidA: {"region": "eu"},
+	}
+
+	g.untagLocked(sidA, "region")
+
+	if _, ok := g.tags["region"]; ok {
+		t.Fatalf("expected empty region bucket to be pruned from tags")
+	}
+	if _, ok := g.sessionTags[sidA]; ok {
+		t.Fatalf("expected sidA removed from sessionTags once it has no tags left")
+	}
+}
+
+func TestUntagAllLockedRemovesEveryTag(t *testing.T) {
+	g := NewGroup("room")
+
+	sidA := facade.SID(1)
+	g.tags = map[string]map[string]map[facade.SID]*Session{
+		"region": {"eu": {sidA: nil}},
+		"level":  {"10": {sidA: nil}},
+	}
+	g.sessionTags = map[facade.SID]map[string]string{
+		sidA: {"region": "eu", "level": "10"},
+	}
+
+	g.untagAllLocked(sidA)
+
+	if len(g.tags) != 0 {
+		t.Fatalf("expected all tag buckets to be pruned, got %+v", g.tags)
+	}
+	if len(g.sessionTags) != 0 {
+		t.Fatalf("expected sessionTags to be empty, got %+v", g.sessionTags)
+	}
+}